@@ -2,248 +2,522 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"github.com/emersion/go-message"
+	"github.com/VinylStage/salvator-epistularum/internal/connpool"
+	"github.com/VinylStage/salvator-epistularum/internal/emlparse"
+	"github.com/VinylStage/salvator-epistularum/internal/fetcher"
+	"github.com/VinylStage/salvator-epistularum/internal/fetcher/imapfetcher"
+	"github.com/VinylStage/salvator-epistularum/internal/fetcher/popfetcher"
+	"github.com/VinylStage/salvator-epistularum/internal/meta"
+	"github.com/VinylStage/salvator-epistularum/internal/render"
+	"github.com/VinylStage/salvator-epistularum/internal/store"
+	"github.com/VinylStage/salvator-epistularum/internal/store/flat"
+	"github.com/VinylStage/salvator-epistularum/internal/store/maildir"
+	"github.com/VinylStage/salvator-epistularum/internal/syncstate"
+	"github.com/emersion/go-message/mail"
 	"github.com/joho/godotenv"
 	"github.com/knadh/go-pop3"
-	"io"
 	"log"
+	"log/slog"
 	"mime"
-	"mime/multipart"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+// newStore builds the message Store selected via the STORE env var,
+// defaulting to the flat .eml writer the tool has always used.
+func newStore(dir string) (store.Store, error) {
+	switch os.Getenv("STORE") {
+	case "maildir":
+		return maildir.New(dir)
+	case "", "flat":
+		return flat.New(dir)
+	default:
+		return nil, fmt.Errorf("unknown STORE %q (want flat or maildir)", os.Getenv("STORE"))
+	}
+}
+
+// runConfig is the set of flags that apply uniformly to every folder a
+// run touches.
+type runConfig struct {
+	workers    int
+	reset      bool
+	sinceTime  time.Time
+	keepDays   int
+	msgTimeout time.Duration
+	watch      bool
+}
+
 func main() {
-	err := godotenv.Load()
-	if err != nil {
+	since := flag.String("since", "", "only fetch messages with a Date header on or after this RFC3339 timestamp")
+	reset := flag.Bool("reset", false, "ignore recorded sync state and re-fetch every message")
+	keepDays := flag.Int("keep-days", 0, "DELE messages this many days after they were fetched (0 disables, POP3 only)")
+	workers := flag.Int("workers", 4, "number of connections fetching and processing mail concurrently")
+	msgTimeout := flag.Duration("msg-timeout", 60*time.Second, "per-message timeout before a worker gives up on a hung fetch")
+	watch := flag.Bool("watch", false, "after the initial sync, keep watching for new mail and re-sync as it arrives (IMAP IDLE only)")
+	flag.Parse()
+
+	var sinceTime time.Time
+	if *since != "" {
+		t, err := time.Parse(time.RFC3339, *since)
+		if err != nil {
+			log.Fatalf("Invalid --since: %v", err)
+		}
+		sinceTime = t
+	}
+
+	if err := godotenv.Load(); err != nil {
 		log.Fatal("Error loading .env file")
 	}
 
 	email := os.Getenv("EMAIL")
 	password := os.Getenv("PASSWORD")
-	pop3Server := os.Getenv("POP3_SERVER")
-	pop3PortStr := os.Getenv("POP3_PORT")
 
-	pop3Port, err := strconv.Atoi(pop3PortStr)
-	if err != nil {
-		log.Fatalf("Invalid POP3_PORT: %v", err)
+	cfg := runConfig{
+		workers:    *workers,
+		reset:      *reset,
+		sinceTime:  sinceTime,
+		keepDays:   *keepDays,
+		msgTimeout: *msgTimeout,
+		watch:      *watch,
 	}
 
-	// Initialize the client.
-	p := pop3.New(pop3.Opt{
-		Host:       pop3Server,
-		Port:       pop3Port,
-		TLSEnabled: false,
-	})
+	protocol := os.Getenv("PROTOCOL")
+	if protocol == "" {
+		protocol = "pop3"
+	}
+
+	switch protocol {
+	case "pop3":
+		if cfg.watch {
+			log.Println("--watch has no effect on PROTOCOL=pop3; POP3 has no IDLE equivalent")
+		}
+		pop3Port, err := strconv.Atoi(os.Getenv("POP3_PORT"))
+		if err != nil {
+			log.Fatalf("Invalid POP3_PORT: %v", err)
+		}
+		p := pop3.New(pop3.Opt{
+			Host:       os.Getenv("POP3_SERVER"),
+			Port:       pop3Port,
+			TLSEnabled: false,
+		})
+		runFolder(popfetcher.Dial(p, email, password), "", cfg)
+
+	case "imap":
+		imapServer := os.Getenv("IMAP_SERVER")
+		folders := strings.Split(os.Getenv("IMAP_FOLDERS"), ",")
+		if len(folders) == 1 && strings.TrimSpace(folders[0]) == "" {
+			folders = []string{"INBOX"}
+		}
+		for i, folder := range folders {
+			folders[i] = strings.TrimSpace(folder)
+			runFolder(imapfetcher.Dial(imapServer, email, password, folders[i]), folders[i], cfg)
+		}
+		if cfg.watch {
+			var wg sync.WaitGroup
+			for _, folder := range folders {
+				wg.Add(1)
+				go func(folder string) {
+					defer wg.Done()
+					watchFolder(imapfetcher.Dial(imapServer, email, password, folder), folder, cfg)
+				}(folder)
+			}
+			wg.Wait()
+		}
+
+	default:
+		log.Fatalf("Unknown PROTOCOL %q (want pop3 or imap)", protocol)
+	}
+}
 
-	// Create a new connection. POP3 connections are stateful and should end
-	// with a Quit() once the opreations are done.
-	c, err := p.NewConn()
+// watchFolder opens a dedicated connection for folder and blocks on IMAP
+// IDLE, re-running runFolder each time the server reports new mail. It
+// returns once the underlying Fetcher doesn't support IDLE, the IDLE
+// session ends with an error, or it can't connect at all - --watch is
+// best-effort continuous mode, not a supervisor that restarts on failure.
+func watchFolder(dial func() (fetcher.Fetcher, error), folder string, cfg runConfig) {
+	conn, err := dial()
 	if err != nil {
-		log.Fatal(err)
+		log.Printf("watch %s: connect: %v", folder, err)
+		return
+	}
+	defer conn.Quit()
+
+	idler, ok := conn.(fetcher.Idler)
+	if !ok {
+		log.Printf("watch %s: fetcher doesn't support IDLE", folder)
+		return
 	}
-	defer c.Quit()
 
-	// Authenticate.
-	if err := c.Auth(email, password); err != nil {
-		log.Fatal(err)
+	for {
+		if err := idler.Idle(func() { runFolder(dial, folder, cfg) }); err != nil {
+			log.Printf("watch %s: idle: %v", folder, err)
+			return
+		}
 	}
+}
 
-	// Print the total number of messages and their size.d
-	count, size, _ := c.Stat()
-	fmt.Println("total messages=", count, "size=", size)
+// newLogger opens (creating if necessary) logDir/mail.jsonl and returns a
+// structured logger writing one JSON object per line to it, so the
+// archive's diagnostics can be queried with jq instead of grepped out of
+// free-form text.
+func newLogger(logDir, folder string) *slog.Logger {
+	logFilePath := filepath.Join(logDir, "mail.jsonl")
+	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Fatalf("❌ Failed to open %s: %v", logFilePath, err)
+	}
+	logger := slog.New(slog.NewJSONHandler(logFile, nil))
+	if folder != "" {
+		logger = logger.With("folder", folder)
+	}
+	return logger
+}
 
-	// Pull the list of all message IDs and their sizes
-	msgs, _ := c.List(0)
+// fatal logs err as a structured error and exits, for setup failures a
+// run can't recover from.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
 
+// runFolder archives every message reachable through dial into
+// backup/<folder> (or plain backup/ when folder is empty, i.e. POP3's
+// flat mailbox), using its own worker pool, Store and sync state.
+func runFolder(dial func() (fetcher.Fetcher, error), folder string, cfg runConfig) {
 	mailDir := "backup"
 	logDir := "logs"
-
+	if folder != "" {
+		mailDir = filepath.Join("backup", folder)
+		logDir = filepath.Join("logs", folder)
+	}
 	os.MkdirAll(mailDir, 0755)
 	os.MkdirAll(logDir, 0755)
 
-	logFilePath := filepath.Join(logDir, "mail.log")
-	logFile, err := os.OpenFile(logFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	logger := newLogger(logDir, folder)
+
+	pool, err := connpool.New(dial, cfg.workers)
 	if err != nil {
-		log.Fatalf("❌ Failed to create log file: %v", err)
+		fatal(logger, "connect", err)
 	}
-	defer logFile.Close()
-	log.SetOutput(logFile)
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
+	defer pool.Close()
 
-	for _, msg := range msgs {
-		fmt.Printf("📨 Processing message ID: %d\n", msg.ID)
+	conn := pool.Get()
+	count, size, _ := conn.Stat()
+	label := folder
+	if label == "" {
+		label = "(default)"
+	}
+	fmt.Printf("folder=%s total messages=%d size=%d\n", label, count, size)
 
-		entity, err := c.Retr(msg.ID)
-		if err != nil {
-			log.Printf("❌ Failed to retrieve message ID %d: %v", msg.ID, err)
-			continue
+	msgs, err := conn.List()
+	pool.Put(conn)
+	if err != nil {
+		fatal(logger, "list messages", err)
+	}
+
+	msgStore, err := newStore(mailDir)
+	if err != nil {
+		fatal(logger, "open store", err)
+	}
+
+	statePath := filepath.Join(logDir, "syncstate.json")
+	if cfg.reset {
+		if err := os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+			fatal(logger, "reset sync state", err)
 		}
+	}
+	syncSt, err := syncstate.Open(statePath)
+	if err != nil {
+		fatal(logger, "open sync state", err)
+	}
 
-		// Save raw .eml
-		emlPath := filepath.Join(mailDir, fmt.Sprintf("mail_%d.eml", msg.ID))
-		f, err := os.Create(emlPath)
-		if err != nil {
-			log.Printf("❌ Failed to save message ID %d: %v", msg.ID, err)
-			continue
+	// Producer: feed message indices to the workers in list order.
+	jobs := make(chan int)
+	go func() {
+		for idx := range msgs {
+			jobs <- idx
 		}
-		if err := entity.WriteTo(f); err != nil {
-			log.Printf("❌ Failed to write message ID %d to file: %v", msg.ID, err)
+		close(jobs)
+	}()
+
+	type result struct {
+		id  int
+		uid string
+		err error
+	}
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				info := msgs[idx]
+				conn := pool.Get()
+
+				uid, err := processMessageWithTimeout(conn, info, msgStore, syncSt, cfg.reset, cfg.sinceTime, mailDir, cfg.msgTimeout, logger)
+				if err != nil && errors.Is(err, context.DeadlineExceeded) {
+					// conn may still be mid-fetch; returning it to the pool
+					// could let another worker issue commands on it at the
+					// same time, which neither go-pop3 nor go-imap support.
+					// Redial a replacement so the pool doesn't shrink by one
+					// every time a message times out.
+					logger.Warn("dropping connection after timeout", "id", info.ID)
+					if derr := pool.Drop(conn); derr != nil {
+						logger.Error("redial after timeout", "id", info.ID, "error", derr)
+					}
+				} else {
+					pool.Put(conn)
+				}
+
+				results <- result{id: info.ID, uid: uid, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Track the UID -> message ID mapping so a later --keep-days prune
+	// can issue DELE against messages still on the server.
+	idByUID := make(map[string]int, len(msgs))
+	for res := range results {
+		if res.err != nil {
+			logger.Error("process message", "id", res.id, "error", res.err)
+			continue
 		}
-		f.Close()
+		idByUID[res.uid] = res.id
+	}
 
-		// Log Content-Type after saving .eml
-		contentType, params, err := entity.Header.ContentType()
+	if cfg.keepDays > 0 {
+		cutoff := time.Now().AddDate(0, 0, -cfg.keepDays)
+		stale, err := syncSt.Prune(cutoff)
 		if err != nil {
-			log.Printf("⚠️ Failed to parse Content-Type for message %d: %v", msg.ID, err)
-		} else {
-			log.Printf("📌 mail_%d Content-Type: %s; boundary=%s", msg.ID, contentType, params["boundary"])
-		}
-
-		// Log header info
-		fmt.Println("📨 Subject:", decodeMIMEHeader(entity.Header.Get("Subject")))
-		fmt.Println("📬 From:", decodeMIMEHeader(entity.Header.Get("From")))
-		fmt.Println("📅 Date:", entity.Header.Get("Date"))
-
-		// Full header dump
-		fmt.Println("🧾 All Headers:")
-		decoder := new(mime.WordDecoder)
-		fields := entity.Header.Fields()
-		for fields.Next() {
-			key := fields.Key()
-			value := fields.Value()
-			decoded, err := decoder.DecodeHeader(value)
-			if err != nil {
-				decoded = "[Decode Error] " + value
-			}
-			fmt.Printf("  %s: %s\n", key, decoded)
+			logger.Warn("prune sync state", "error", err)
 		}
 
-		// Save full MIME body (entity) for debug
-		rawBodyPath := filepath.Join(mailDir, fmt.Sprintf("mail_%d_rawbody.txt", msg.ID))
-		var buf bytes.Buffer
-		if err := entity.WriteTo(&buf); err != nil {
-			log.Printf("❌ Failed to buffer entity for message %d: %v", msg.ID, err)
-		} else {
-			if err := os.WriteFile(rawBodyPath, buf.Bytes(), 0644); err != nil {
-				log.Printf("⚠️ Failed to write raw body file for message %d: %v", msg.ID, err)
+		conn := pool.Get()
+		if deleter, ok := conn.(fetcher.Deleter); ok {
+			for _, uid := range stale {
+				id, ok := idByUID[uid]
+				if !ok {
+					continue
+				}
+				if err := deleter.Dele(id); err != nil {
+					logger.Warn("DELE message", "id", id, "uid", uid, "error", err)
+				}
 			}
+		} else if len(stale) > 0 {
+			logger.Warn("keep-days set but fetcher can't delete messages", "stale_count", len(stale))
 		}
+		pool.Put(conn)
+	}
+}
 
-		// Body
-		body := extractPlainText(entity)
-		log.Printf("📝 mail_%d body result: %s", msg.ID, summarizeBodyPreview(body))
-		fmt.Println("📄 Body:\n" + body)
-		fmt.Println("====================================\n")
+// processMessageWithTimeout runs processMessage under a deadline so a
+// server that stalls mid-stream for one message can't block the whole
+// run. Neither go-pop3 nor go-imap gives their fetch calls a
+// cancellation hook, so on timeout the underlying call is left running;
+// the caller is responsible for not reusing conn afterwards.
+func processMessageWithTimeout(conn fetcher.Fetcher, info fetcher.MessageInfo, msgStore store.Store, syncSt *syncstate.State, reset bool, sinceTime time.Time, mailDir string, timeout time.Duration, logger *slog.Logger) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type outcome struct {
+		uid string
+		err error
 	}
+	done := make(chan outcome, 1)
+	go func() {
+		uid, err := processMessage(conn, info, msgStore, syncSt, reset, sinceTime, mailDir, logger)
+		done <- outcome{uid, err}
+	}()
 
+	select {
+	case o := <-done:
+		return o.uid, o.err
+	case <-ctx.Done():
+		logger.Warn("message timed out", "id", info.ID, "timeout", timeout.String())
+		return "", ctx.Err()
+	}
 }
-func extractPlainText(e *message.Entity) string {
-	mt, params, _ := mime.ParseMediaType(e.Header.Get("Content-Type"))
-
-	if strings.HasPrefix(mt, "multipart/") {
-		boundary := params["boundary"]
-		mr := multipart.NewReader(e.Body, boundary)
-		parts := []*multipart.Part{}
-		for {
-			p, err := mr.NextPart()
-			if err == io.EOF {
-				break
-			} else if err != nil {
-				log.Printf("⚠️ Failed to read multipart: %v", err)
-				break
-			}
 
-			parts = append(parts, p)
-		}
+// processMessage fetches, stores, parses and logs a single message. It
+// returns the message's UID so the caller can track sync state, even
+// when the message was skipped because it had already been synced.
+func processMessage(conn fetcher.Fetcher, info fetcher.MessageInfo, msgStore store.Store, syncSt *syncstate.State, reset bool, sinceTime time.Time, mailDir string, logger *slog.Logger) (string, error) {
+	uid := info.UID
+	if uid == "" {
+		uid = strconv.Itoa(info.ID)
+	}
 
-		for _, p := range parts {
-			partType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
-			b, err := io.ReadAll(p)
-			if err != nil {
-				log.Printf("⚠️ Failed to read part body: %v", err)
-				continue
-			}
-			if partType == "text/plain" {
-				return string(b)
-			}
-			if partType == "text/html" {
-				return "[HTML] " + string(b)
-			}
+	if !reset && syncSt.Has(uid) {
+		fmt.Printf("⏭️  Skipping already-synced message ID: %d (uid=%s)\n", info.ID, uid)
+		return uid, nil
+	}
+
+	fmt.Printf("📨 Processing message ID: %d\n", info.ID)
+
+	entity, err := conn.Retr(info.ID)
+	if err != nil {
+		return uid, fmt.Errorf("retrieve message %d: %w", info.ID, err)
+	}
+
+	if !sinceTime.IsZero() {
+		mailHeader := mail.Header{Header: entity.Header}
+		if msgDate, err := mailHeader.Date(); err == nil && msgDate.Before(sinceTime) {
+			fmt.Printf("⏭️  Skipping message ID %d: Date %s is before --since\n", info.ID, msgDate)
+			return uid, nil
 		}
-		log.Printf("⚠️ No usable part (text/plain or text/html) found in multipart message.")
-		return "[Multipart: No plain or HTML body detected]"
-	} else if mt == "text/plain" || mt == "text/html" {
-		b, err := io.ReadAll(e.Body)
+	}
+
+	// Save via the configured Store (flat .eml files or Maildir++),
+	// carrying along any flags (e.g. \Seen) the IMAP backend reported.
+	// The stored path depends on which backend is active (a flat
+	// mail_<uid>.eml vs. a Maildir new/ or cur/ entry), so ask the store
+	// for it rather than guessing.
+	storedPath, err := msgStore.Put(entity, uid, info.Flags)
+	if err != nil {
+		return uid, fmt.Errorf("save message %d: %w", info.ID, err)
+	}
+	if err := syncSt.Mark(uid, storedPath, time.Now()); err != nil {
+		logger.Warn("record sync state", "id", info.ID, "error", err)
+	}
+
+	// Log Content-Type after saving .eml
+	contentType, params, err := entity.Header.ContentType()
+	if err != nil {
+		logger.Warn("parse content-type", "id", info.ID, "error", err)
+	} else {
+		logger.Info("content-type", "id", info.ID, "content_type", contentType, "boundary", params["boundary"])
+	}
+
+	// Log header info
+	fmt.Println("📨 Subject:", decodeMIMEHeader(entity.Header.Get("Subject")))
+	fmt.Println("📬 From:", decodeMIMEHeader(entity.Header.Get("From")))
+	fmt.Println("📅 Date:", entity.Header.Get("Date"))
+
+	// Full header dump
+	fmt.Println("🧾 All Headers:")
+	decoder := new(mime.WordDecoder)
+	fields := entity.Header.Fields()
+	for fields.Next() {
+		key := fields.Key()
+		value := fields.Value()
+		decoded, err := decoder.DecodeHeader(value)
 		if err != nil {
-			log.Printf("⚠️ Failed to read entity body: %v", err)
-			return "[Body Read Error]"
-		}
-		htmlStr := string(b)
-		if mt == "text/html" {
-			if strings.Contains(htmlStr, "<img") && !strings.Contains(htmlStr, "<p>") {
-				imgs := extractImageSrcs(htmlStr)
-				if len(imgs) > 0 {
-					return "[이미지 기반 메일입니다]\n이미지 URL:\n" + strings.Join(imgs, "\n")
+			decoded = "[Decode Error] " + value
+		}
+		fmt.Printf("  %s: %s\n", key, decoded)
+	}
+
+	// Save full MIME body (entity) for debug
+	rawBodyPath := filepath.Join(mailDir, fmt.Sprintf("mail_%d_rawbody.txt", info.ID))
+	var buf bytes.Buffer
+	if err := entity.WriteTo(&buf); err != nil {
+		logger.Error("buffer entity", "id", info.ID, "error", err)
+	} else if err := os.WriteFile(rawBodyPath, buf.Bytes(), 0644); err != nil {
+		logger.Warn("write raw body file", "id", info.ID, "error", err)
+	}
+
+	// Parse the MIME tree and save any attachments alongside the raw
+	// .eml so the archive renders offline.
+	parsed, perr := emlparse.ParseMessage(bytes.NewReader(buf.Bytes()))
+	if perr != nil {
+		logger.Warn("parse message", "id", info.ID, "error", perr)
+		parsed = &emlparse.Message{}
+	} else {
+		attachDir := filepath.Join(mailDir, fmt.Sprintf("mail_%d", info.ID))
+		if err := parsed.SaveAttachments(attachDir); err != nil {
+			logger.Warn("save attachments", "id", info.ID, "error", err)
+		}
+
+		if parsed.HTMLBody != "" {
+			sanitized, err := render.SanitizeHTML(strings.NewReader(parsed.HTMLBody))
+			if err != nil {
+				logger.Warn("sanitize HTML body", "id", info.ID, "error", err)
+			} else {
+				htmlPath := filepath.Join(mailDir, fmt.Sprintf("mail_%d.html", info.ID))
+				if err := os.WriteFile(htmlPath, sanitized, 0644); err != nil {
+					logger.Warn("write HTML companion", "id", info.ID, "error", err)
 				}
-				return "[이미지 기반 본문입니다. GUI에서 확인해주세요]"
 			}
-			return "[HTML] " + htmlStr
 		}
-		return htmlStr
 	}
-	return "[No Body]"
-}
 
-func decodeMIMEHeader(s string) string {
-	decoded, err := (&mime.WordDecoder{}).DecodeHeader(s)
-	if err != nil {
-		return s
+	// Body
+	bodyClass := classifyBody(parsed, logger)
+	body := bodyPreview(parsed, bodyClass, logger)
+	logger.Info("body classified", "id", info.ID, "class", bodyClass)
+	fmt.Println("📄 Body:\n" + body)
+	fmt.Println("====================================\n")
+
+	sidecar := meta.Build(entity.Header, parsed, bodyClass)
+	if err := meta.WriteFile(mailDir, info.ID, sidecar); err != nil {
+		logger.Warn("write metadata sidecar", "id", info.ID, "error", err)
 	}
-	return decoded
+
+	return uid, nil
 }
 
-func extractImageSrcs(html string) []string {
-	var urls []string
-	start := 0
-	for {
-		imgIndex := strings.Index(html[start:], "<img")
-		if imgIndex == -1 {
-			break
+// classifyBody categorizes a parsed message's body as plain, html,
+// image-only (an HTML body that's just wrapped images), or
+// multipart-empty (neither a text nor an HTML part was found).
+func classifyBody(msg *emlparse.Message, logger *slog.Logger) string {
+	if msg.TextBody != "" {
+		return "plain"
+	}
+	if msg.HTMLBody != "" {
+		imageOnly, _, err := render.IsImageOnly(strings.NewReader(msg.HTMLBody))
+		if err != nil {
+			logger.Warn("inspect HTML body", "error", err)
+		} else if imageOnly {
+			return "image-only"
 		}
-		imgStart := start + imgIndex
-		srcIndex := strings.Index(html[imgStart:], "src=\"")
-		if srcIndex == -1 {
-			break
+		return "html"
+	}
+	return "multipart-empty"
+}
+
+// bodyPreview renders the console/log preview text for a parsed message
+// according to its body classification.
+func bodyPreview(msg *emlparse.Message, class string, logger *slog.Logger) string {
+	switch class {
+	case "plain":
+		return msg.TextBody
+	case "image-only":
+		_, imgs, err := render.IsImageOnly(strings.NewReader(msg.HTMLBody))
+		if err == nil && len(imgs) > 0 {
+			return "[이미지 기반 메일입니다]\n이미지 URL:\n" + strings.Join(imgs, "\n")
 		}
-		srcStart := imgStart + srcIndex + len("src=\"")
-		srcEnd := strings.Index(html[srcStart:], "\"")
-		if srcEnd == -1 {
-			break
+		return "[이미지 기반 본문입니다. GUI에서 확인해주세요]"
+	case "html":
+		text, err := render.HTMLToText(strings.NewReader(msg.HTMLBody))
+		if err != nil {
+			logger.Warn("render HTML body to text", "error", err)
+			return "[HTML] " + msg.HTMLBody
 		}
-		url := html[srcStart : srcStart+srcEnd]
-		urls = append(urls, url)
-		start = srcStart + srcEnd
+		return "[HTML] " + text
+	default:
+		return "[No Body]"
 	}
-	return urls
 }
 
-// summarizeBodyPreview returns a short tag for the type of body content.
-func summarizeBodyPreview(s string) string {
-	if len(s) == 0 {
-		return "[EMPTY]"
-	}
-	if strings.HasPrefix(s, "[HTML]") {
-		return "[HTML]"
-	}
-	if strings.HasPrefix(s, "[이미지 기반") {
-		return "[IMG-ONLY]"
-	}
-	if strings.HasPrefix(s, "[Multipart") {
-		return "[MULTIPART]"
+func decodeMIMEHeader(s string) string {
+	decoded, err := (&mime.WordDecoder{}).DecodeHeader(s)
+	if err != nil {
+		return s
 	}
-	return "[PLAIN]"
+	return decoded
 }
@@ -0,0 +1,73 @@
+// Package connpool manages a small set of already-connected Fetchers.
+// Both go-pop3 connections and go-imap clients are stateful and not safe
+// to share across goroutines, so a worker that wants to fetch mail in
+// parallel with others needs its own connection; this pool hands those
+// out and takes them back instead of every worker dialing for itself.
+package connpool
+
+import "github.com/VinylStage/salvator-epistularum/internal/fetcher"
+
+// Pool holds size connected Fetchers, opened once up front.
+type Pool struct {
+	dial  func() (fetcher.Fetcher, error)
+	conns chan fetcher.Fetcher
+}
+
+// New dials size Fetchers using dial and returns a Pool ready to hand
+// them out via Get. If any dial fails, every connection opened so far is
+// closed and the error is returned. dial is retained so Drop can redial a
+// replacement later.
+func New(dial func() (fetcher.Fetcher, error), size int) (*Pool, error) {
+	if size < 1 {
+		size = 1
+	}
+
+	pool := &Pool{dial: dial, conns: make(chan fetcher.Fetcher, size)}
+	for i := 0; i < size; i++ {
+		f, err := dial()
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		pool.conns <- f
+	}
+	return pool, nil
+}
+
+// Get blocks until a connection is available.
+func (pool *Pool) Get() fetcher.Fetcher {
+	return <-pool.conns
+}
+
+// Put returns a connection to the pool so another caller can reuse it.
+// Every connection obtained via Get must eventually be returned, either
+// with Put or, if it's known to be unusable (e.g. after a timeout), with
+// Drop.
+func (pool *Pool) Put(f fetcher.Fetcher) {
+	pool.conns <- f
+}
+
+// Drop discards f, which the caller knows is unusable (e.g. left mid-fetch
+// after a timeout, so it can't safely be reused), and dials a replacement
+// so the pool doesn't permanently lose that slot of capacity. If the
+// redial fails, the error is returned and the pool is left one connection
+// short.
+func (pool *Pool) Drop(f fetcher.Fetcher) error {
+	f.Quit()
+
+	replacement, err := pool.dial()
+	if err != nil {
+		return err
+	}
+	pool.conns <- replacement
+	return nil
+}
+
+// Close quits every connection currently idle in the pool. Callers must
+// Put back every outstanding connection before calling Close.
+func (pool *Pool) Close() {
+	close(pool.conns)
+	for f := range pool.conns {
+		f.Quit()
+	}
+}
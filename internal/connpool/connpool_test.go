@@ -0,0 +1,63 @@
+package connpool
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/VinylStage/salvator-epistularum/internal/fetcher"
+	"github.com/emersion/go-message"
+)
+
+// fakeFetcher is a zero-cost stand-in for a real POP3/IMAP connection, so
+// the benchmark below measures the pool and worker scheduling overhead in
+// isolation instead of network or parsing cost.
+type fakeFetcher struct{}
+
+func (fakeFetcher) Stat() (int, int, error)             { return 0, 0, nil }
+func (fakeFetcher) List() ([]fetcher.MessageInfo, error) { return nil, nil }
+func (fakeFetcher) Retr(id int) (*message.Entity, error) { return &message.Entity{}, nil }
+func (fakeFetcher) Quit() error                          { return nil }
+
+// BenchmarkWorkerPool drives the pool through the same Get/Retr/Put shape
+// cmd.runFolder uses, with a fixed worker count pulling from a shared job
+// queue sized in the thousands of messages, to show the pool holds up on
+// mailboxes that size.
+func BenchmarkWorkerPool(b *testing.B) {
+	const (
+		workers     = 4
+		numMessages = 4000
+	)
+
+	pool, err := New(func() (fetcher.Fetcher, error) { return fakeFetcher{}, nil }, workers)
+	if err != nil {
+		b.Fatalf("new pool: %v", err)
+	}
+	defer pool.Close()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		jobs := make(chan int)
+		go func() {
+			for id := 0; id < numMessages; id++ {
+				jobs <- id
+			}
+			close(jobs)
+		}()
+
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for id := range jobs {
+					conn := pool.Get()
+					if _, err := conn.Retr(id); err != nil {
+						b.Error(err)
+					}
+					pool.Put(conn)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,210 @@
+// Package emlparse parses a raw RFC 5322 / MIME message into a structured
+// Message tree, recursing through multipart/mixed, multipart/alternative
+// and multipart/related parts. Transfer-encoding (quoted-printable,
+// base64) and charset decoding are handled by go-message and its charset
+// extension, so callers always get UTF-8 text back.
+package emlparse
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/emersion/go-message"
+	_ "github.com/emersion/go-message/charset" // register non-UTF-8 charset decoders
+)
+
+// Attachment is a single leaf part of a message that isn't the primary
+// text or HTML body: a downloadable file, or an inline part referenced
+// from the HTML body via a Content-ID.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string
+	Disposition string
+	Data        []byte
+}
+
+// Part is a node in a message's Content-Type tree, used to summarize its
+// MIME structure without keeping the bodies around.
+type Part struct {
+	ContentType string `json:"content_type"`
+	Parts       []Part `json:"parts,omitempty"`
+}
+
+// Message is the structured result of parsing an EML file.
+type Message struct {
+	TextBody string
+	HTMLBody string
+	// Attachments are parts meant to be downloaded (Content-Disposition:
+	// attachment, or no Content-ID).
+	Attachments []Attachment
+	// Embedded are inline parts referenced from HTMLBody via cid: URLs.
+	Embedded []Attachment
+	// Parts is the Content-Type tree of the message, root first.
+	Parts Part
+}
+
+// ParseMessage reads a raw MIME message from r and walks its part tree,
+// collecting the text/HTML bodies, every attachment, and a summary of the
+// Content-Type structure.
+func ParseMessage(r io.Reader) (*Message, error) {
+	entity, err := message.Read(r)
+	if err != nil && !message.IsUnknownCharset(err) {
+		return nil, fmt.Errorf("emlparse: read message: %w", err)
+	}
+
+	msg := &Message{}
+	part, err := walk(entity, msg)
+	if err != nil {
+		return nil, err
+	}
+	msg.Parts = part
+	return msg, nil
+}
+
+func walk(e *message.Entity, msg *Message) (Part, error) {
+	mt, params, _ := mime.ParseMediaType(e.Header.Get("Content-Type"))
+	part := Part{ContentType: mt}
+
+	if strings.HasPrefix(mt, "multipart/") {
+		mr := e.MultipartReader()
+		if mr == nil {
+			return part, fmt.Errorf("emlparse: %s declared but no multipart body", mt)
+		}
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return part, fmt.Errorf("emlparse: read part of %s: %w", mt, err)
+			}
+			child, err := walk(p, msg)
+			if err != nil {
+				return part, err
+			}
+			part.Parts = append(part.Parts, child)
+		}
+		return part, nil
+	}
+
+	disposition, dispParams, _ := mime.ParseMediaType(e.Header.Get("Content-Disposition"))
+	contentID := strings.Trim(e.Header.Get("Content-Id"), "<>")
+
+	switch {
+	case mt == "text/plain" && disposition != "attachment":
+		b, err := io.ReadAll(e.Body)
+		if err != nil {
+			return part, fmt.Errorf("emlparse: read text/plain body: %w", err)
+		}
+		msg.TextBody += string(b)
+	case mt == "text/html" && disposition != "attachment":
+		b, err := io.ReadAll(e.Body)
+		if err != nil {
+			return part, fmt.Errorf("emlparse: read text/html body: %w", err)
+		}
+		msg.HTMLBody += string(b)
+	default:
+		b, err := io.ReadAll(e.Body)
+		if err != nil {
+			return part, fmt.Errorf("emlparse: read attachment body: %w", err)
+		}
+
+		filename := dispParams["filename"]
+		if filename == "" {
+			filename = params["name"]
+		}
+		if filename == "" {
+			filename = fmt.Sprintf("part-%d", len(msg.Attachments)+len(msg.Embedded)+1)
+		}
+
+		att := Attachment{
+			Filename:    filename,
+			ContentType: mt,
+			ContentID:   contentID,
+			Disposition: disposition,
+			Data:        b,
+		}
+		if contentID != "" && disposition != "attachment" {
+			msg.Embedded = append(msg.Embedded, att)
+		} else {
+			msg.Attachments = append(msg.Attachments, att)
+		}
+	}
+	return part, nil
+}
+
+// SaveAttachments writes every attachment and embedded part into
+// dir/attachments, then rewrites any cid: reference in HTMLBody to the
+// saved file's path relative to dir's parent (<base of dir>/attachments/
+// <name>), so an HTML file written beside dir renders offline.
+func (m *Message) SaveAttachments(dir string) error {
+	if len(m.Attachments) == 0 && len(m.Embedded) == 0 {
+		return nil
+	}
+
+	attachDir := filepath.Join(dir, "attachments")
+	if err := os.MkdirAll(attachDir, 0755); err != nil {
+		return fmt.Errorf("emlparse: create %s: %w", attachDir, err)
+	}
+
+	usedNames := make(map[string]struct{})
+	save := func(att Attachment) (string, error) {
+		name := dedupeFilename(usedNames, sanitizeFilename(att.Filename))
+		if err := os.WriteFile(filepath.Join(attachDir, name), att.Data, 0644); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+
+	for _, att := range m.Attachments {
+		if _, err := save(att); err != nil {
+			return fmt.Errorf("emlparse: save attachment %s: %w", att.Filename, err)
+		}
+	}
+	for _, att := range m.Embedded {
+		name, err := save(att)
+		if err != nil {
+			return fmt.Errorf("emlparse: save embedded attachment %s: %w", att.Filename, err)
+		}
+		if att.ContentID != "" {
+			rel := filepath.Join(filepath.Base(dir), "attachments", name)
+			m.HTMLBody = strings.ReplaceAll(m.HTMLBody, "cid:"+att.ContentID, rel)
+		}
+	}
+	return nil
+}
+
+// sanitizeFilename strips any directory components from an
+// attacker-controlled filename so an attachment can't be written outside
+// of the attachments directory.
+func sanitizeFilename(name string) string {
+	name = filepath.Base(name)
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		return "attachment"
+	}
+	return name
+}
+
+// dedupeFilename returns name unchanged the first time it's seen in used,
+// and otherwise appends "-2", "-3", ... before the extension, so two
+// attachments or inline parts sharing a filename (a generic
+// "image001.png", a twice-forwarded "invoice.pdf") don't silently
+// overwrite each other on disk. The chosen name is recorded in used.
+func dedupeFilename(used map[string]struct{}, name string) string {
+	candidate := name
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		if _, taken := used[candidate]; !taken {
+			break
+		}
+		candidate = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	used[candidate] = struct{}{}
+	return candidate
+}
@@ -0,0 +1,112 @@
+package emlparse
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testMessage = "From: a@example.com\r\n" +
+	"Subject: Test\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=\"outer\"\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"inner\"\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=\"utf-8\"\r\n" +
+	"\r\n" +
+	"Hello plain text.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html; charset=\"utf-8\"\r\n" +
+	"\r\n" +
+	"<html><body>Hello <img src=\"cid:img1\"></body></html>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-Disposition: inline; filename=\"image.png\"\r\n" +
+	"Content-Id: <img1>\r\n" +
+	"\r\n" +
+	"fake-png-bytes\r\n" +
+	"--outer\r\n" +
+	"Content-Type: application/pdf\r\n" +
+	"Content-Disposition: attachment; filename=\"invoice.pdf\"\r\n" +
+	"\r\n" +
+	"fake-pdf-bytes\r\n" +
+	"--outer--\r\n"
+
+func TestParseMessageMultipart(t *testing.T) {
+	msg, err := ParseMessage(strings.NewReader(testMessage))
+	if err != nil {
+		t.Fatalf("ParseMessage: %v", err)
+	}
+
+	if !strings.Contains(msg.TextBody, "Hello plain text.") {
+		t.Errorf("TextBody = %q, want it to contain the plain-text part", msg.TextBody)
+	}
+	if !strings.Contains(msg.HTMLBody, "cid:img1") {
+		t.Errorf("HTMLBody = %q, want it to still reference cid:img1 before SaveAttachments rewrites it", msg.HTMLBody)
+	}
+
+	if len(msg.Attachments) != 1 || msg.Attachments[0].Filename != "invoice.pdf" {
+		t.Errorf("Attachments = %+v, want exactly one invoice.pdf attachment", msg.Attachments)
+	}
+	if len(msg.Embedded) != 1 || msg.Embedded[0].ContentID != "img1" {
+		t.Errorf("Embedded = %+v, want exactly one part with ContentID img1", msg.Embedded)
+	}
+}
+
+func TestSaveAttachmentsRewritesCIDAndDedupesNames(t *testing.T) {
+	msg := &Message{
+		HTMLBody: `<img src="cid:img1">`,
+		Attachments: []Attachment{
+			{Filename: "invoice.pdf", Data: []byte("first")},
+			{Filename: "invoice.pdf", Data: []byte("second")},
+		},
+		Embedded: []Attachment{
+			{Filename: "image.png", ContentID: "img1", Data: []byte("png-bytes")},
+		},
+	}
+
+	dir := t.TempDir()
+	if err := msg.SaveAttachments(dir); err != nil {
+		t.Fatalf("SaveAttachments: %v", err)
+	}
+
+	if strings.Contains(msg.HTMLBody, "cid:img1") {
+		t.Errorf("HTMLBody = %q, want the cid: reference rewritten to a relative path", msg.HTMLBody)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, "attachments"))
+	if err != nil {
+		t.Fatalf("read attachments dir: %v", err)
+	}
+	names := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		names[e.Name()] = true
+	}
+
+	if !names["invoice.pdf"] || !names["invoice-2.pdf"] {
+		t.Errorf("attachments dir = %v, want both invoice.pdf and a deduped invoice-2.pdf", names)
+	}
+	if !names["image.png"] {
+		t.Errorf("attachments dir = %v, want the embedded image.png", names)
+	}
+}
+
+func TestSanitizeFilenameStripsDirectoryComponents(t *testing.T) {
+	cases := map[string]string{
+		"report.pdf":                "report.pdf",
+		"../../etc/passwd":          "passwd",
+		"/etc/passwd":               "passwd",
+		"":                          "attachment",
+		string(filepath.Separator): "attachment",
+	}
+	for in, want := range cases {
+		if got := sanitizeFilename(in); got != want {
+			t.Errorf("sanitizeFilename(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Package fetcher defines the mail-source boundary the backup loop uses,
+// so it can archive a POP3 mailbox or an IMAP folder the same way.
+package fetcher
+
+import "github.com/emersion/go-message"
+
+// MessageInfo describes one message available from a Fetcher, independent
+// of whether it came from POP3 or IMAP.
+type MessageInfo struct {
+	// ID is the source-specific identifier needed to Retr this message
+	// again within the same session (a POP3 message number, or an IMAP
+	// sequence number).
+	ID int
+	// UID is a stable identifier for the message (POP3 UIDL, or IMAP
+	// UID), used to dedupe across runs.
+	UID string
+	// Size is the message size in bytes, if the source reports one.
+	Size int
+	// Flags holds IMAP-style flags (e.g. "\Seen", "\Flagged") when the
+	// source tracks them. POP3 sources leave this nil.
+	Flags []string
+}
+
+// Fetcher is the minimum a mail source needs to support so the backup
+// loop can archive it regardless of protocol.
+type Fetcher interface {
+	// Stat returns the number of messages and their total size.
+	Stat() (count, size int, err error)
+	// List returns every message currently available.
+	List() ([]MessageInfo, error)
+	// Retr fetches and parses message id into a MIME entity.
+	Retr(id int) (*message.Entity, error)
+	// Quit closes the underlying connection.
+	Quit() error
+}
+
+// Deleter is implemented by Fetchers that can remove a message from the
+// server once it has been archived. POP3's DELE has no IMAP equivalent
+// in this package yet.
+type Deleter interface {
+	Dele(id int) error
+}
+
+// Idler is implemented by Fetchers that can block waiting for new mail to
+// arrive instead of requiring the caller to poll, e.g. IMAP IDLE. POP3 has
+// no equivalent in this package yet.
+type Idler interface {
+	Idle(onNewMail func()) error
+}
@@ -0,0 +1,149 @@
+// Package imapfetcher adapts a go-imap client, scoped to a single
+// selected folder, to the fetcher.Fetcher interface, so modern mail
+// providers can be archived folder-by-folder instead of through POP3's
+// flat mailbox.
+package imapfetcher
+
+import (
+	"fmt"
+
+	"github.com/VinylStage/salvator-epistularum/internal/fetcher"
+	"github.com/emersion/go-imap"
+	imapclient "github.com/emersion/go-imap/client"
+	"github.com/emersion/go-message"
+)
+
+// Fetcher adapts a logged-in *imapclient.Client with folder selected to
+// fetcher.Fetcher.
+type Fetcher struct {
+	client *imapclient.Client
+	folder string
+}
+
+// Dial returns a function that connects to addr over TLS, logs in, and
+// selects folder, suitable for connpool.New.
+func Dial(addr, email, password, folder string) func() (fetcher.Fetcher, error) {
+	return func() (fetcher.Fetcher, error) {
+		c, err := imapclient.DialTLS(addr, nil)
+		if err != nil {
+			return nil, fmt.Errorf("imapfetcher: dial %s: %w", addr, err)
+		}
+		if err := c.Login(email, password); err != nil {
+			c.Logout()
+			return nil, fmt.Errorf("imapfetcher: login: %w", err)
+		}
+		if _, err := c.Select(folder, false); err != nil {
+			c.Logout()
+			return nil, fmt.Errorf("imapfetcher: select %s: %w", folder, err)
+		}
+		return &Fetcher{client: c, folder: folder}, nil
+	}
+}
+
+// Stat returns the folder's message count. IMAP has no single "total
+// size" figure the way POP3 does, so size is always 0.
+func (f *Fetcher) Stat() (int, int, error) {
+	status, err := f.client.Status(f.folder, []imap.StatusItem{imap.StatusMessages})
+	if err != nil {
+		return 0, 0, fmt.Errorf("imapfetcher: status %s: %w", f.folder, err)
+	}
+	return int(status.Messages), 0, nil
+}
+
+// List returns every message in the folder with its UID, size and flags.
+func (f *Fetcher) List() ([]fetcher.MessageInfo, error) {
+	status, err := f.client.Status(f.folder, []imap.StatusItem{imap.StatusMessages})
+	if err != nil {
+		return nil, fmt.Errorf("imapfetcher: status %s: %w", f.folder, err)
+	}
+	if status.Messages == 0 {
+		return nil, nil
+	}
+
+	seqset := new(imap.SeqSet)
+	seqset.AddRange(1, status.Messages)
+
+	messages := make(chan *imap.Message, status.Messages)
+	done := make(chan error, 1)
+	go func() {
+		done <- f.client.Fetch(seqset, []imap.FetchItem{imap.FetchUid, imap.FetchFlags, imap.FetchRFC822Size}, messages)
+	}()
+
+	infos := make([]fetcher.MessageInfo, 0, status.Messages)
+	for msg := range messages {
+		infos = append(infos, fetcher.MessageInfo{
+			ID:    int(msg.SeqNum),
+			UID:   fmt.Sprintf("%d", msg.Uid),
+			Size:  int(msg.Size),
+			Flags: append([]string(nil), msg.Flags...),
+		})
+	}
+	if err := <-done; err != nil {
+		return nil, fmt.Errorf("imapfetcher: fetch %s: %w", f.folder, err)
+	}
+	return infos, nil
+}
+
+// Retr fetches the full RFC822 body of message id (a sequence number)
+// and parses it into a MIME entity.
+func (f *Fetcher) Retr(id int) (*message.Entity, error) {
+	seqset := new(imap.SeqSet)
+	seqset.AddNum(uint32(id))
+
+	section := &imap.BodySectionName{}
+	messages := make(chan *imap.Message, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- f.client.Fetch(seqset, []imap.FetchItem{section.FetchItem()}, messages)
+	}()
+
+	msg := <-messages
+	if err := <-done; err != nil {
+		return nil, fmt.Errorf("imapfetcher: fetch message %d: %w", id, err)
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("imapfetcher: message %d not found in %s", id, f.folder)
+	}
+
+	r := msg.GetBody(section)
+	if r == nil {
+		return nil, fmt.Errorf("imapfetcher: message %d in %s has no body", id, f.folder)
+	}
+
+	entity, err := message.Read(r)
+	if err != nil && !message.IsUnknownCharset(err) {
+		return nil, fmt.Errorf("imapfetcher: parse message %d: %w", id, err)
+	}
+	return entity, nil
+}
+
+// Quit logs out of the IMAP session.
+func (f *Fetcher) Quit() error {
+	return f.client.Logout()
+}
+
+// Idle blocks, calling onNewMail each time the server reports new
+// messages in the selected folder, until the server ends the IDLE
+// session or an error occurs. This backs the tool's continuous mode for
+// providers (Gmail, most modern ones) that support IMAP IDLE instead of
+// requiring polling.
+func (f *Fetcher) Idle(onNewMail func()) error {
+	updates := make(chan imapclient.Update, 1)
+	f.client.Updates = updates
+	defer func() { f.client.Updates = nil }()
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() { done <- f.client.Idle(stop, nil) }()
+
+	for {
+		select {
+		case update := <-updates:
+			if _, ok := update.(*imapclient.MailboxUpdate); ok {
+				onNewMail()
+			}
+		case err := <-done:
+			return err
+		}
+	}
+}
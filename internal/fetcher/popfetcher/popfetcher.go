@@ -0,0 +1,71 @@
+// Package popfetcher adapts a POP3 connection to the fetcher.Fetcher
+// interface.
+package popfetcher
+
+import (
+	"fmt"
+
+	"github.com/VinylStage/salvator-epistularum/internal/fetcher"
+	"github.com/emersion/go-message"
+	"github.com/knadh/go-pop3"
+)
+
+// Fetcher adapts a single authenticated *pop3.Conn to fetcher.Fetcher.
+type Fetcher struct {
+	conn *pop3.Conn
+}
+
+// Dial returns a function that opens and authenticates a new POP3
+// connection, suitable for connpool.New.
+func Dial(p *pop3.Client, email, password string) func() (fetcher.Fetcher, error) {
+	return func() (fetcher.Fetcher, error) {
+		conn, err := p.NewConn()
+		if err != nil {
+			return nil, fmt.Errorf("popfetcher: dial: %w", err)
+		}
+		if err := conn.Auth(email, password); err != nil {
+			conn.Quit()
+			return nil, fmt.Errorf("popfetcher: auth: %w", err)
+		}
+		return &Fetcher{conn: conn}, nil
+	}
+}
+
+// Stat returns the mailbox's message count and total size.
+func (f *Fetcher) Stat() (int, int, error) {
+	return f.conn.Stat()
+}
+
+// List returns every message in the mailbox along with its UIDL. POP3
+// has no concept of flags, so MessageInfo.Flags is always nil.
+func (f *Fetcher) List() ([]fetcher.MessageInfo, error) {
+	items, err := f.conn.List(0)
+	if err != nil {
+		return nil, fmt.Errorf("popfetcher: list: %w", err)
+	}
+
+	infos := make([]fetcher.MessageInfo, 0, len(items))
+	for _, item := range items {
+		var uid string
+		if uidls, err := f.conn.Uidl(item.ID); err == nil && len(uidls) > 0 {
+			uid = uidls[0].UID
+		}
+		infos = append(infos, fetcher.MessageInfo{ID: item.ID, UID: uid, Size: item.Size})
+	}
+	return infos, nil
+}
+
+// Retr fetches and parses message id.
+func (f *Fetcher) Retr(id int) (*message.Entity, error) {
+	return f.conn.Retr(id)
+}
+
+// Dele marks message id for deletion on Quit, per the POP3 protocol.
+func (f *Fetcher) Dele(id int) error {
+	return f.conn.Dele(id)
+}
+
+// Quit closes the connection.
+func (f *Fetcher) Quit() error {
+	return f.conn.Quit()
+}
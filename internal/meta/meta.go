@@ -0,0 +1,136 @@
+// Package meta builds the per-message JSON sidecar the archive writes
+// alongside each saved message, so the backup can be queried with jq or
+// indexed downstream without re-parsing every EML file.
+package meta
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/VinylStage/salvator-epistularum/internal/emlparse"
+	"github.com/emersion/go-message"
+	emmail "github.com/emersion/go-message/mail"
+)
+
+// AttachmentInfo summarizes one attachment or embedded part without
+// carrying its bytes.
+type AttachmentInfo struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	ContentID   string `json:"content_id,omitempty"`
+	Disposition string `json:"disposition,omitempty"`
+	Size        int    `json:"size"`
+}
+
+// Sidecar is the metadata recorded for one archived message.
+type Sidecar struct {
+	From        string           `json:"from,omitempty"`
+	To          []string         `json:"to,omitempty"`
+	Cc          []string         `json:"cc,omitempty"`
+	Subject     string           `json:"subject,omitempty"`
+	Date        string           `json:"date,omitempty"`
+	MessageID   string           `json:"message_id,omitempty"`
+	InReplyTo   string           `json:"in_reply_to,omitempty"`
+	References  []string         `json:"references,omitempty"`
+	ContentType emlparse.Part    `json:"content_type_tree"`
+	Attachments []AttachmentInfo `json:"attachments,omitempty"`
+	// Body classifies the message as plain, html, image-only (an HTML
+	// body that is just wrapped images), or multipart-empty (no text or
+	// HTML part was found at all).
+	Body string `json:"body"`
+}
+
+var headerDecoder = new(mime.WordDecoder)
+
+// Build collects header and body metadata for one archived message.
+// A From or Date that fails to parse is left empty, and a Subject, To or
+// Cc that fails to decode keeps its raw header value, rather than failing
+// the whole sidecar.
+func Build(header message.Header, parsed *emlparse.Message, bodyClass string) *Sidecar {
+	s := &Sidecar{
+		ContentType: parsed.Parts,
+		Body:        bodyClass,
+		MessageID:   strings.Trim(header.Get("Message-Id"), "<>"),
+	}
+
+	if subject, err := headerDecoder.DecodeHeader(header.Get("Subject")); err == nil {
+		s.Subject = subject
+	} else {
+		s.Subject = header.Get("Subject")
+	}
+
+	if addrs, err := mail.ParseAddressList(header.Get("From")); err == nil && len(addrs) > 0 {
+		s.From = addrs[0].String()
+	}
+	s.To = addressStrings(header.Get("To"))
+	s.Cc = addressStrings(header.Get("Cc"))
+
+	if date, err := (emmail.Header{Header: header}).Date(); err == nil {
+		s.Date = date.Format(time.RFC3339)
+	}
+
+	if inReplyTo := strings.TrimSpace(header.Get("In-Reply-To")); inReplyTo != "" {
+		s.InReplyTo = strings.Trim(inReplyTo, "<>")
+	}
+	if refs := strings.Fields(header.Get("References")); len(refs) > 0 {
+		s.References = make([]string, len(refs))
+		for i, r := range refs {
+			s.References[i] = strings.Trim(r, "<>")
+		}
+	}
+
+	for _, att := range parsed.Attachments {
+		s.Attachments = append(s.Attachments, attachmentInfo(att))
+	}
+	for _, att := range parsed.Embedded {
+		s.Attachments = append(s.Attachments, attachmentInfo(att))
+	}
+
+	return s
+}
+
+func attachmentInfo(att emlparse.Attachment) AttachmentInfo {
+	return AttachmentInfo{
+		Filename:    att.Filename,
+		ContentType: att.ContentType,
+		ContentID:   att.ContentID,
+		Disposition: att.Disposition,
+		Size:        len(att.Data),
+	}
+}
+
+// addressStrings parses an address-list header, falling back to the raw
+// header value if it doesn't parse as a standard address list.
+func addressStrings(header string) []string {
+	if strings.TrimSpace(header) == "" {
+		return nil
+	}
+	addrs, err := mail.ParseAddressList(header)
+	if err != nil {
+		return []string{header}
+	}
+	out := make([]string, len(addrs))
+	for i, a := range addrs {
+		out[i] = a.String()
+	}
+	return out
+}
+
+// WriteFile marshals s as indented JSON to dir/mail_<id>.json.
+func WriteFile(dir string, id int, s *Sidecar) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("meta: marshal sidecar for message %d: %w", id, err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("mail_%d.json", id))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("meta: write %s: %w", path, err)
+	}
+	return nil
+}
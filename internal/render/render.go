@@ -0,0 +1,182 @@
+// Package render turns HTML mail bodies into something safe and readable
+// outside of a browser: plain text for the console/log preview, and
+// sanitized HTML for an on-disk companion file. Both are built on a real
+// HTML tokenizer rather than string scanning, so malformed markup (single
+// quotes, missing whitespace, CDATA) doesn't break them.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+)
+
+// HTMLToText converts an HTML document into readable plain text. Links
+// are preserved as Markdown-style "[text](url)", and script/style content
+// is dropped entirely.
+func HTMLToText(r io.Reader) (string, error) {
+	z := html.NewTokenizer(r)
+
+	var sb strings.Builder
+	var linkHref string
+	var linkText strings.Builder
+	inLink := false
+	skipDepth := 0
+
+	flushLink := func() {
+		text := strings.TrimSpace(linkText.String())
+		switch {
+		case text != "" && linkHref != "" && text != linkHref:
+			fmt.Fprintf(&sb, "[%s](%s)", text, linkHref)
+		case linkHref != "":
+			sb.WriteString(linkHref)
+		default:
+			sb.WriteString(text)
+		}
+		linkText.Reset()
+		linkHref = ""
+		inLink = false
+	}
+
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if err := z.Err(); err != io.EOF {
+				return "", fmt.Errorf("render: tokenize html: %w", err)
+			}
+			if inLink {
+				flushLink()
+			}
+			return collapseBlankLines(sb.String()), nil
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tag, _ := z.TagName()
+			switch string(tag) {
+			case "script", "style":
+				skipDepth++
+			case "a":
+				if skipDepth == 0 {
+					inLink = true
+					linkHref = tagAttr(z, "href")
+				}
+			case "br", "p", "div", "tr", "li":
+				if skipDepth == 0 {
+					sb.WriteString("\n")
+				}
+			}
+
+		case html.EndTagToken:
+			tag, _ := z.TagName()
+			switch string(tag) {
+			case "script", "style":
+				if skipDepth > 0 {
+					skipDepth--
+				}
+			case "a":
+				if skipDepth == 0 && inLink {
+					flushLink()
+				}
+			case "p", "div", "tr", "li":
+				if skipDepth == 0 {
+					sb.WriteString("\n")
+				}
+			}
+
+		case html.TextToken:
+			if skipDepth > 0 {
+				continue
+			}
+			text := string(z.Text())
+			if inLink {
+				linkText.WriteString(text)
+			} else {
+				sb.WriteString(text)
+			}
+		}
+	}
+}
+
+// tagAttr returns the value of attr on the tokenizer's current tag, or
+// "" if it isn't set.
+func tagAttr(z *html.Tokenizer, attr string) string {
+	for {
+		key, val, more := z.TagAttr()
+		if string(key) == attr {
+			return string(val)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// collapseBlankLines trims each line and drops repeated blank lines left
+// behind by block-level tags.
+func collapseBlankLines(s string) string {
+	lines := strings.Split(s, "\n")
+	out := make([]string, 0, len(lines))
+	prevBlank := false
+	for _, l := range lines {
+		l = strings.TrimSpace(l)
+		if l == "" {
+			if prevBlank {
+				continue
+			}
+			prevBlank = true
+		} else {
+			prevBlank = false
+		}
+		out = append(out, l)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
+
+// IsImageOnly reports whether an HTML body is effectively just images:
+// it contains at least one <img> and no <p> text block. It returns every
+// image src found along the way.
+func IsImageOnly(r io.Reader) (bool, []string, error) {
+	z := html.NewTokenizer(r)
+
+	var imgs []string
+	hasParagraph := false
+
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if err := z.Err(); err != io.EOF {
+				return false, nil, fmt.Errorf("render: tokenize html: %w", err)
+			}
+			break
+		}
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			continue
+		}
+
+		tag, _ := z.TagName()
+		switch string(tag) {
+		case "img":
+			if src := tagAttr(z, "src"); src != "" {
+				imgs = append(imgs, src)
+			}
+		case "p":
+			hasParagraph = true
+		}
+	}
+
+	return len(imgs) > 0 && !hasParagraph, imgs, nil
+}
+
+// SanitizeHTML strips scripts, styles, and other unsafe markup from r
+// using a UGC-safe policy, returning HTML fit to write to disk for
+// viewing in a browser.
+func SanitizeHTML(r io.Reader) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := io.Copy(&buf, bluemonday.UGCPolicy().SanitizeReader(r)); err != nil {
+		return nil, fmt.Errorf("render: sanitize html: %w", err)
+	}
+	return buf.Bytes(), nil
+}
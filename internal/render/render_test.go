@@ -0,0 +1,76 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLToTextPreservesLinksAndBlockBreaks(t *testing.T) {
+	html := `<html><body><p>Hello <b>world</b></p><p>See <a href="https://example.com">our site</a>.</p></body></html>`
+
+	got, err := HTMLToText(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("HTMLToText: %v", err)
+	}
+
+	want := "Hello world\n\nSee [our site](https://example.com)."
+	if got != want {
+		t.Errorf("HTMLToText(%q) = %q, want %q", html, got, want)
+	}
+}
+
+func TestHTMLToTextDropsScriptAndStyle(t *testing.T) {
+	html := `<html><body><style>p{color:red}</style><script>alert(1)</script><p>Visible</p></body></html>`
+
+	got, err := HTMLToText(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("HTMLToText: %v", err)
+	}
+	if strings.Contains(got, "alert") || strings.Contains(got, "color:red") {
+		t.Errorf("HTMLToText(%q) = %q, want script/style content dropped", html, got)
+	}
+	if !strings.Contains(got, "Visible") {
+		t.Errorf("HTMLToText(%q) = %q, want it to keep the visible text", html, got)
+	}
+}
+
+func TestIsImageOnly(t *testing.T) {
+	cases := []struct {
+		name     string
+		html     string
+		wantOnly bool
+		wantImgs int
+	}{
+		{"image only", `<div><img src="cid:1"><img src="cid:2"></div>`, true, 2},
+		{"image with paragraph", `<p>Check this out</p><img src="cid:1">`, false, 1},
+		{"no images", `<p>Just text</p>`, false, 0},
+	}
+
+	for _, c := range cases {
+		only, imgs, err := IsImageOnly(strings.NewReader(c.html))
+		if err != nil {
+			t.Fatalf("%s: IsImageOnly: %v", c.name, err)
+		}
+		if only != c.wantOnly {
+			t.Errorf("%s: IsImageOnly() only = %v, want %v", c.name, only, c.wantOnly)
+		}
+		if len(imgs) != c.wantImgs {
+			t.Errorf("%s: IsImageOnly() imgs = %v, want %d entries", c.name, imgs, c.wantImgs)
+		}
+	}
+}
+
+func TestSanitizeHTMLStripsScripts(t *testing.T) {
+	html := `<p>Hello</p><script>alert('xss')</script>`
+
+	out, err := SanitizeHTML(strings.NewReader(html))
+	if err != nil {
+		t.Fatalf("SanitizeHTML: %v", err)
+	}
+	if strings.Contains(string(out), "<script") {
+		t.Errorf("SanitizeHTML(%q) = %q, want <script> stripped", html, out)
+	}
+	if !strings.Contains(string(out), "Hello") {
+		t.Errorf("SanitizeHTML(%q) = %q, want the safe text preserved", html, out)
+	}
+}
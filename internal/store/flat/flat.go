@@ -0,0 +1,81 @@
+// Package flat stores each message as a single mail_<uid>.eml file, the
+// layout the backup tool used before the store.Store interface existed.
+package flat
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/VinylStage/salvator-epistularum/internal/store"
+)
+
+// Store writes one flat .eml file per message into Dir.
+type Store struct {
+	Dir string
+}
+
+// New returns a flat Store rooted at dir. The directory is created if it
+// does not already exist.
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("flat: create %s: %w", dir, err)
+	}
+	return &Store{Dir: dir}, nil
+}
+
+func (s *Store) path(uid string) string {
+	return filepath.Join(s.Dir, fmt.Sprintf("mail_%s.eml", sanitizeUID(uid)))
+}
+
+// sanitizeUID strips any directory components from a server-reported uid
+// before it's used as a path element, the same guard emlparse applies to
+// attachment filenames - otherwise a POP3 UIDL/IMAP UID containing "../"
+// or an absolute path could write outside Dir.
+func sanitizeUID(uid string) string {
+	uid = filepath.Base(uid)
+	if uid == "" || uid == "." || uid == string(filepath.Separator) {
+		return "invalid-uid"
+	}
+	return uid
+}
+
+// Put writes entity to mail_<uid>.eml, overwriting any existing file, and
+// returns the path written. Flat files have no way to record flags, so
+// flags is ignored.
+func (s *Store) Put(entity store.Entity, uid string, flags []string) (string, error) {
+	path := s.path(uid)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("flat: create mail_%s.eml: %w", uid, err)
+	}
+	defer f.Close()
+
+	if err := entity.WriteTo(f); err != nil {
+		return "", fmt.Errorf("flat: write mail_%s.eml: %w", uid, err)
+	}
+	return path, nil
+}
+
+// List returns every mail_*.eml file currently in Dir.
+func (s *Store) List() ([]store.MessageRef, error) {
+	matches, err := filepath.Glob(filepath.Join(s.Dir, "mail_*.eml"))
+	if err != nil {
+		return nil, fmt.Errorf("flat: list %s: %w", s.Dir, err)
+	}
+
+	refs := make([]store.MessageRef, 0, len(matches))
+	for _, m := range matches {
+		base := strings.TrimSuffix(filepath.Base(m), ".eml")
+		uid := strings.TrimPrefix(base, "mail_")
+		refs = append(refs, store.MessageRef{UID: uid, Path: m})
+	}
+	return refs, nil
+}
+
+// Open opens the file backing ref for reading.
+func (s *Store) Open(ref store.MessageRef) (io.ReadCloser, error) {
+	return os.Open(ref.Path)
+}
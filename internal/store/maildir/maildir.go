@@ -0,0 +1,191 @@
+// Package maildir stores messages in a Maildir++ directory layout
+// (new/, cur/, tmp/) so the backup can be opened directly by mutt, aerc,
+// or any other Maildir-aware client instead of needing a converter.
+package maildir
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/VinylStage/salvator-epistularum/internal/store"
+)
+
+const (
+	subNew = "new"
+	subCur = "cur"
+	subTmp = "tmp"
+)
+
+// Store writes messages into a single Maildir rooted at Dir, using the
+// POP3 UIDL as the stable part of the filename so re-running a sync does
+// not duplicate messages already delivered.
+type Store struct {
+	Dir string
+}
+
+// New creates (if necessary) the new/cur/tmp layout under dir and returns
+// a Store backed by it.
+func New(dir string) (*Store, error) {
+	for _, sub := range []string{subNew, subCur, subTmp} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, fmt.Errorf("maildir: create %s/%s: %w", dir, sub, err)
+		}
+	}
+	return &Store{Dir: dir}, nil
+}
+
+// sanitizeUID strips any directory components from a server-reported uid
+// before it's used as a path element, the same guard emlparse applies to
+// attachment filenames - otherwise a POP3 UIDL/IMAP UID containing "../"
+// or an absolute path could deliver outside Dir.
+func sanitizeUID(uid string) string {
+	uid = filepath.Base(uid)
+	if uid == "" || uid == "." || uid == string(filepath.Separator) {
+		return "invalid-uid"
+	}
+	return uid
+}
+
+// filename builds the Maildir++ base name for uid: "<uid>:2,<flags>".
+// Keying on uid rather than a timestamp/PID pair is what makes repeated
+// syncs idempotent - the same POP3 UIDL always maps to the same file.
+func filename(uid, flags string) string {
+	name := uid
+	if flags != "" {
+		name += ":2," + flags
+	} else {
+		name += ":2,"
+	}
+	return name
+}
+
+// imapToMaildirFlag maps IMAP flags to the single-letter codes used in
+// the Maildir info suffix.
+var imapToMaildirFlag = map[string]byte{
+	`\Seen`:     'S',
+	`\Answered`: 'R',
+	`\Flagged`:  'F',
+	`\Deleted`:  'T',
+	`\Draft`:    'D',
+}
+
+// encodeFlags converts IMAP-style flags into a Maildir info suffix,
+// e.g. ["\Seen", "\Flagged"] -> "FS" (Maildir requires the letters sorted).
+func encodeFlags(flags []string) string {
+	letters := make([]byte, 0, len(flags))
+	for _, f := range flags {
+		if letter, ok := imapToMaildirFlag[f]; ok {
+			letters = append(letters, letter)
+		}
+	}
+	sort.Slice(letters, func(i, j int) bool { return letters[i] < letters[j] })
+	return string(letters)
+}
+
+// Put delivers entity by writing it to tmp/ first and atomically renaming
+// it into place, per the Maildir delivery protocol. Messages that already
+// carry flags (already \Seen on the server, say) are delivered straight
+// into cur/, matching how a real MDA handles mail a client has already
+// seen; otherwise they land in new/. If uid was already delivered by an
+// earlier Put - possibly to the other subdirectory, e.g. a message that
+// picked up \Seen between a --reset resync - the stale file is removed so
+// the same message never shows up twice.
+func (s *Store) Put(entity store.Entity, uid string, flags []string) (string, error) {
+	uid = sanitizeUID(uid)
+
+	existing, err := s.findExisting(uid)
+	if err != nil {
+		return "", fmt.Errorf("maildir: check existing delivery for %s: %w", uid, err)
+	}
+
+	tmpPath := filepath.Join(s.Dir, subTmp, uid)
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("maildir: create tmp file for %s: %w", uid, err)
+	}
+
+	if err := entity.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("maildir: write tmp file for %s: %w", uid, err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("maildir: close tmp file for %s: %w", uid, err)
+	}
+
+	encoded := encodeFlags(flags)
+	sub := subNew
+	if encoded != "" {
+		sub = subCur
+	}
+
+	destPath := filepath.Join(s.Dir, sub, filename(uid, encoded))
+	if err := os.Rename(tmpPath, destPath); err != nil {
+		return "", fmt.Errorf("maildir: deliver %s to %s/: %w", uid, sub, err)
+	}
+
+	if existing != "" && existing != destPath {
+		if err := os.Remove(existing); err != nil {
+			return destPath, fmt.Errorf("maildir: remove stale delivery for %s: %w", uid, err)
+		}
+	}
+	return destPath, nil
+}
+
+// findExisting returns the path of a message previously delivered for uid,
+// in either new/ or cur/, or "" if none exists yet.
+func (s *Store) findExisting(uid string) (string, error) {
+	for _, sub := range []string{subNew, subCur} {
+		entries, err := os.ReadDir(filepath.Join(s.Dir, sub))
+		if err != nil {
+			return "", fmt.Errorf("maildir: list %s: %w", sub, err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() && uidFromFilename(e.Name()) == uid {
+				return filepath.Join(s.Dir, sub, e.Name()), nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// List returns every message currently delivered under new/ or cur/.
+func (s *Store) List() ([]store.MessageRef, error) {
+	var refs []store.MessageRef
+	for _, sub := range []string{subNew, subCur} {
+		entries, err := os.ReadDir(filepath.Join(s.Dir, sub))
+		if err != nil {
+			return nil, fmt.Errorf("maildir: list %s: %w", sub, err)
+		}
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			refs = append(refs, store.MessageRef{
+				UID:  uidFromFilename(e.Name()),
+				Path: filepath.Join(s.Dir, sub, e.Name()),
+			})
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool { return refs[i].UID < refs[j].UID })
+	return refs, nil
+}
+
+// Open opens the file backing ref for reading.
+func (s *Store) Open(ref store.MessageRef) (io.ReadCloser, error) {
+	return os.Open(ref.Path)
+}
+
+// uidFromFilename strips the ":2,<flags>" info suffix, recovering the
+// UIDL that was used to deliver the message.
+func uidFromFilename(name string) string {
+	if i := strings.Index(name, ":2,"); i != -1 {
+		return name[:i]
+	}
+	return name
+}
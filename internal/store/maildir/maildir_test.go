@@ -0,0 +1,94 @@
+package maildir
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeEntity struct{ body string }
+
+func (e fakeEntity) WriteTo(w io.Writer) error {
+	_, err := io.WriteString(w, e.body)
+	return err
+}
+
+func TestPutDeliversToNewOrCurByFlags(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	path, err := s.Put(fakeEntity{"unseen"}, "uid1", nil)
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if filepath.Dir(path) != filepath.Join(s.Dir, subNew) {
+		t.Errorf("Put with no flags delivered to %s, want new/", path)
+	}
+
+	path, err = s.Put(fakeEntity{"seen"}, "uid2", []string{`\Seen`})
+	if err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if filepath.Dir(path) != filepath.Join(s.Dir, subCur) {
+		t.Errorf("Put with \\Seen delivered to %s, want cur/", path)
+	}
+}
+
+func TestPutIsIdempotentAcrossFlagChange(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	firstPath, err := s.Put(fakeEntity{"v1"}, "uid1", nil)
+	if err != nil {
+		t.Fatalf("first Put: %v", err)
+	}
+
+	// Simulate a --reset resync where the message has since been read:
+	// the same uid now arrives with \Seen, so it should move to cur/ and
+	// the stale new/ copy must not be left behind.
+	secondPath, err := s.Put(fakeEntity{"v2"}, "uid1", []string{`\Seen`})
+	if err != nil {
+		t.Fatalf("second Put: %v", err)
+	}
+
+	if _, err := os.Stat(firstPath); !os.IsNotExist(err) {
+		t.Errorf("stale new/ delivery at %s still exists after re-Put with \\Seen", firstPath)
+	}
+
+	refs, err := s.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(refs) != 1 {
+		t.Fatalf("List() = %+v, want exactly one delivered message for uid1", refs)
+	}
+	if refs[0].Path != secondPath {
+		t.Errorf("List() path = %s, want %s", refs[0].Path, secondPath)
+	}
+}
+
+func TestEncodeFlagsSortsLetters(t *testing.T) {
+	got := encodeFlags([]string{`\Flagged`, `\Seen`, `\Answered`})
+	if got != "FRS" {
+		t.Errorf("encodeFlags(...) = %q, want %q", got, "FRS")
+	}
+}
+
+func TestSanitizeUIDStripsDirectoryComponents(t *testing.T) {
+	cases := map[string]string{
+		"123":            "123",
+		"../../etc/cron": "cron",
+		"/etc/passwd":    "passwd",
+		"":                "invalid-uid",
+	}
+	for in, want := range cases {
+		if got := sanitizeUID(in); got != want {
+			t.Errorf("sanitizeUID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
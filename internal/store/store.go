@@ -0,0 +1,41 @@
+// Package store defines the persistence boundary between the fetch
+// pipeline and whatever on-disk layout a downstream mail client expects.
+package store
+
+import "io"
+
+// MessageRef identifies a single message that has already been persisted
+// by a Store, so it can be listed or reopened later without redoing the
+// fetch.
+type MessageRef struct {
+	// UID is the identifier the backend used to name the message, usually
+	// the POP3 UIDL value.
+	UID string
+	// Path is the absolute or backup-relative path to the stored message.
+	Path string
+}
+
+// Entity is the subset of *message.Entity a Store needs: just enough to
+// serialize it to disk without this package importing go-message.
+type Entity interface {
+	WriteTo(w io.Writer) error
+}
+
+// Store persists a retrieved message and makes previously persisted
+// messages discoverable again. Implementations decide the on-disk layout
+// (flat files, Maildir++, ...); callers should not assume anything beyond
+// this interface.
+type Store interface {
+	// Put writes entity to the backend, keyed by uid, and returns the path
+	// it was written to so callers don't have to guess a backend's naming
+	// scheme. flags carries IMAP-style flags (e.g. "\Seen") when the
+	// source tracks them; a backend that has no use for them is free to
+	// ignore it. Calling Put twice with the same uid should be idempotent
+	// where the backend allows it.
+	Put(entity Entity, uid string, flags []string) (string, error)
+	// List returns every message currently known to the store.
+	List() ([]MessageRef, error)
+	// Open returns a reader for the message referenced by ref. Callers
+	// must Close it.
+	Open(ref MessageRef) (io.ReadCloser, error)
+}
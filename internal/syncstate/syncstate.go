@@ -0,0 +1,106 @@
+// Package syncstate tracks which POP3 UIDs have already been downloaded
+// so repeated runs of the backup tool can skip mail they have already
+// fetched instead of re-downloading the whole mailbox every time.
+package syncstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Record is the persisted bookkeeping for one previously-fetched message.
+type Record struct {
+	UID       string    `json:"uid"`
+	Path      string    `json:"path"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// State is a JSON-file-backed set of Records, safe for concurrent use.
+type State struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]Record
+}
+
+// Open loads the state file at path, returning an empty State if it does
+// not exist yet.
+func Open(path string) (*State, error) {
+	s := &State{path: path, records: make(map[string]Record)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("syncstate: read %s: %w", path, err)
+	}
+	if len(data) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(data, &s.records); err != nil {
+		return nil, fmt.Errorf("syncstate: parse %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Has reports whether uid has already been downloaded.
+func (s *State) Has(uid string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.records[uid]
+	return ok
+}
+
+// Mark records that uid was saved to path at fetchedAt and persists the
+// state file immediately, so a crash mid-run loses at most one message.
+func (s *State) Mark(uid, path string, fetchedAt time.Time) error {
+	s.mu.Lock()
+	s.records[uid] = Record{UID: uid, Path: path, FetchedAt: fetchedAt}
+	s.mu.Unlock()
+	return s.save()
+}
+
+// Prune removes every record fetched before cutoff, persists the result,
+// and returns the UIDs that were removed so the caller can issue DELE for
+// them.
+func (s *State) Prune(cutoff time.Time) ([]string, error) {
+	s.mu.Lock()
+	var removed []string
+	for uid, rec := range s.records {
+		if rec.FetchedAt.Before(cutoff) {
+			removed = append(removed, uid)
+			delete(s.records, uid)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, s.save()
+}
+
+func (s *State) save() error {
+	// Hold the lock through the write and rename: concurrent saves share
+	// the same .tmp file and must not interleave or land out of order.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := json.MarshalIndent(s.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("syncstate: marshal: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("syncstate: create dir: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("syncstate: write %s: %w", tmp, err)
+	}
+	return os.Rename(tmp, s.path)
+}
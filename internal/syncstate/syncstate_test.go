@@ -0,0 +1,77 @@
+package syncstate
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMarkPersistsAndHasReportsIt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syncstate.json")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if s.Has("uid1") {
+		t.Fatalf("Has(uid1) = true on a fresh state")
+	}
+
+	if err := s.Mark("uid1", "/backup/uid1.eml", time.Unix(1000, 0)); err != nil {
+		t.Fatalf("Mark: %v", err)
+	}
+	if !s.Has("uid1") {
+		t.Errorf("Has(uid1) = false right after Mark")
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if !reopened.Has("uid1") {
+		t.Errorf("Has(uid1) = false after reopening the persisted state file")
+	}
+}
+
+func TestOpenMissingFileStartsEmpty(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "does-not-exist.json"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if s.Has("anything") {
+		t.Errorf("Has() = true on a state backed by a nonexistent file")
+	}
+}
+
+func TestPruneRemovesRecordsBeforeCutoffOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "syncstate.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	old := time.Unix(1000, 0)
+	recent := time.Unix(5000, 0)
+	cutoff := time.Unix(3000, 0)
+
+	if err := s.Mark("stale", "/backup/stale.eml", old); err != nil {
+		t.Fatalf("Mark stale: %v", err)
+	}
+	if err := s.Mark("fresh", "/backup/fresh.eml", recent); err != nil {
+		t.Fatalf("Mark fresh: %v", err)
+	}
+
+	removed, err := s.Prune(cutoff)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "stale" {
+		t.Errorf("Prune(cutoff) removed = %v, want [stale]", removed)
+	}
+	if s.Has("stale") {
+		t.Errorf("Has(stale) = true after it was pruned")
+	}
+	if !s.Has("fresh") {
+		t.Errorf("Has(fresh) = false, want the record fetched after cutoff to survive Prune")
+	}
+}